@@ -0,0 +1,89 @@
+package main
+
+import (
+	"math/rand/v2"
+	"time"
+
+	"github.com/mlange-42/ark/ecs"
+)
+
+// BenchResult is one BroadPhase implementation's measured performance over an
+// identical seeded scene.
+type BenchResult struct {
+	Name        string
+	PairsPerSec float64
+	MsPerFrame  float64
+}
+
+// RunBroadPhaseBenchmark seeds an identical scene of entityCount rectangles for
+// each BroadPhase implementation and steps it for frames ticks, reporting
+// pairs/sec and ms/frame so callers can pick the right structure for their
+// entity count and clustering pattern.
+func RunBroadPhaseBenchmark(entityCount, frames int) []BenchResult {
+	impls := []struct {
+		name string
+		bp   BroadPhase
+	}{
+		{"SAP (Y-band parallel)", newSAPBroadPhase(ScreenHeight)},
+		{"Uniform grid", newGridBroadPhase(32)},
+		{"BVH (SAH, rebuild every 30)", newBVHBroadPhase(30)},
+	}
+
+	results := make([]BenchResult, 0, len(impls))
+	for _, impl := range impls {
+		results = append(results, benchmarkBroadPhase(impl.name, impl.bp, entityCount, frames))
+	}
+	return results
+}
+
+// benchBody is the benchmark's own minimal stand-in for a moving rectangle; it
+// doesn't touch the ark World's component storage, only the entity identity it
+// needs to report pairs.
+type benchBody struct {
+	entity ecs.Entity
+	rect   Rect
+	vel    Velocity
+}
+
+func benchmarkBroadPhase(name string, bp BroadPhase, entityCount, frames int) BenchResult {
+	// A fixed seed gives every implementation the exact same scene and motion.
+	rng := rand.New(rand.NewPCG(1, 2))
+	world := ecs.NewWorld(entityCount)
+
+	bodies := make([]benchBody, entityCount)
+	for i := range bodies {
+		w := 2 + rng.Float64()*18
+		h := 2 + rng.Float64()*18
+		bodies[i] = benchBody{
+			entity: world.NewEntity(),
+			rect: Rect{
+				X: rng.Float64() * (ScreenWidth - w),
+				Y: rng.Float64() * (ScreenHeight - h),
+				W: w,
+				H: h,
+			},
+			vel: Velocity{X: -1 + rng.Float64()*2, Y: -1 + rng.Float64()*2},
+		}
+		bp.Insert(bodies[i].entity, AABBFromRect(bodies[i].rect))
+	}
+
+	var totalPairs int64
+	start := time.Now()
+	for f := 0; f < frames; f++ {
+		for i := range bodies {
+			b := &bodies[i]
+			b.rect.X += b.vel.X
+			b.rect.Y += b.vel.Y
+			handleScreenBoundaryCollision(&b.rect, &b.vel, ScreenWidth, ScreenHeight)
+			bp.Update(b.entity, AABBFromRect(b.rect))
+		}
+		bp.QueryPairs(func(a, b ecs.Entity) { totalPairs++ })
+	}
+	elapsed := time.Since(start)
+
+	return BenchResult{
+		Name:        name,
+		PairsPerSec: float64(totalPairs) / elapsed.Seconds(),
+		MsPerFrame:  float64(elapsed.Milliseconds()) / float64(frames),
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"math"
+	"sort"
+
+	"github.com/mlange-42/ark/ecs"
+)
+
+// AABB is an axis-aligned bounding box expressed as min/max bounds, the shape
+// every BroadPhase implementation operates on. It mirrors a Rect's extent
+// without tying the broad phase to Rect's X/Y/W/H representation.
+type AABB struct {
+	MinX, MinY, MaxX, MaxY float64
+}
+
+// AABBFromRect converts a Rect into the broad phase's AABB representation.
+func AABBFromRect(r Rect) AABB {
+	return AABB{MinX: r.X, MinY: r.Y, MaxX: r.X + r.W, MaxY: r.Y + r.H}
+}
+
+// Overlaps reports whether two AABBs intersect on both axes.
+func (a AABB) Overlaps(b AABB) bool {
+	return a.MinX < b.MaxX && a.MaxX > b.MinX && a.MinY < b.MaxY && a.MaxY > b.MinY
+}
+
+// Union returns the smallest AABB containing both a and b.
+func (a AABB) Union(b AABB) AABB {
+	return AABB{
+		MinX: math.Min(a.MinX, b.MinX),
+		MinY: math.Min(a.MinY, b.MinY),
+		MaxX: math.Max(a.MaxX, b.MaxX),
+		MaxY: math.Max(a.MaxY, b.MaxY),
+	}
+}
+
+// halfPerimeter is the 2D stand-in for surface area, used as the SAH cost proxy.
+func (a AABB) halfPerimeter() float64 {
+	return (a.MaxX - a.MinX) + (a.MaxY - a.MinY)
+}
+
+// BroadPhaseKind selects which BroadPhase implementation a Simulation builds;
+// see SimulationConfig. SAP is the default — sapBroadPhase, gridBroadPhase and
+// bvhBroadPhase are all just choices, and RunBroadPhaseBenchmark compares them
+// on identical scenes.
+type BroadPhaseKind int
+
+const (
+	SAPBroadPhase BroadPhaseKind = iota
+	GridBroadPhase
+	BVHBroadPhase
+)
+
+// newBroadPhase builds the BroadPhase a SimulationConfig asks for, falling back
+// to a default for any zero-valued tuning knob (grid cell size 32, BVH rebuild
+// every 30 calls).
+func newBroadPhase(config SimulationConfig) BroadPhase {
+	switch config.BroadPhaseKind {
+	case GridBroadPhase:
+		cellSize := config.GridCellSize
+		if cellSize <= 0 {
+			cellSize = 32
+		}
+		return newGridBroadPhase(cellSize)
+	case BVHBroadPhase:
+		rebuildEvery := config.BVHRebuildEvery
+		if rebuildEvery <= 0 {
+			rebuildEvery = 30
+		}
+		return newBVHBroadPhase(rebuildEvery)
+	default:
+		return newSAPBroadPhase(config.Height)
+	}
+}
+
+// BroadPhase finds overlapping pairs of entities from their AABBs. Simulation
+// picks one implementation at construction time per SimulationConfig;
+// sapBroadPhase, gridBroadPhase and bvhBroadPhase all satisfy it, and
+// RunBroadPhaseBenchmark compares them on identical scenes.
+type BroadPhase interface {
+	// Insert adds a new entity with its current AABB.
+	Insert(entity ecs.Entity, box AABB)
+	// Update refreshes an already-inserted entity's AABB.
+	Update(entity ecs.Entity, box AABB)
+	// Remove forgets an entity entirely.
+	Remove(entity ecs.Entity)
+	// QueryPairs reports every pair of entities whose AABBs currently overlap.
+	QueryPairs(fn func(a, b ecs.Entity))
+}
+
+// emitSortedPairs calls fn for every pair in pairs, ordered by (a.ID(), b.ID()).
+// Every BroadPhase implementation collects into pairs before calling this
+// rather than calling fn straight out of a map iteration, so the order
+// collisions resolve in — and therefore Simulation.Checksum — doesn't depend
+// on Go's randomized map iteration order.
+func emitSortedPairs(pairs []pairKey, fn func(a, b ecs.Entity)) {
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].a.ID() != pairs[j].a.ID() {
+			return pairs[i].a.ID() < pairs[j].a.ID()
+		}
+		return pairs[i].b.ID() < pairs[j].b.ID()
+	})
+	for _, p := range pairs {
+		fn(p.a, p.b)
+	}
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"math"
+	"sort"
+
+	"github.com/mlange-42/ark/ecs"
+)
+
+// bvhNode is a node of the top-down BVH. Leaves hold a single entity; internal
+// nodes hold the union AABB of their two children.
+type bvhNode struct {
+	box         AABB
+	left, right *bvhNode
+	entity      ecs.Entity
+	leaf        bool
+}
+
+// bvhBroadPhase is a top-down BVH BroadPhase with SAH-guided splits. The tree is
+// fully rebuilt every rebuildEvery calls to QueryPairs; on the frames in between
+// it's only refit (leaf AABBs pulled up to their ancestors without changing the
+// tree's shape), trading a little traversal efficiency for a much cheaper update.
+// An Insert or Remove forces a full rebuild on the next QueryPairs, since a
+// refit can't add or drop leaves.
+type bvhBroadPhase struct {
+	boxes        map[ecs.Entity]AABB
+	root         *bvhNode
+	frame        int
+	rebuildEvery int
+}
+
+func newBVHBroadPhase(rebuildEvery int) *bvhBroadPhase {
+	if rebuildEvery < 1 {
+		rebuildEvery = 1
+	}
+	return &bvhBroadPhase{
+		boxes:        make(map[ecs.Entity]AABB),
+		rebuildEvery: rebuildEvery,
+	}
+}
+
+func (bp *bvhBroadPhase) Insert(e ecs.Entity, box AABB) {
+	bp.boxes[e] = box
+	bp.root = nil
+}
+
+func (bp *bvhBroadPhase) Update(e ecs.Entity, box AABB) { bp.boxes[e] = box }
+
+func (bp *bvhBroadPhase) Remove(e ecs.Entity) {
+	delete(bp.boxes, e)
+	bp.root = nil
+}
+
+func (bp *bvhBroadPhase) QueryPairs(fn func(a, b ecs.Entity)) {
+	if bp.root == nil || bp.frame%bp.rebuildEvery == 0 {
+		bp.rebuild()
+	} else {
+		bp.refit(bp.root)
+	}
+	bp.frame++
+
+	pairs := make([]pairKey, 0)
+	selfCollideBVH(bp.root, &pairs)
+	emitSortedPairs(pairs, fn)
+}
+
+func (bp *bvhBroadPhase) rebuild() {
+	entities := make([]ecs.Entity, 0, len(bp.boxes))
+	for e := range bp.boxes {
+		entities = append(entities, e)
+	}
+	bp.root = bp.build(entities)
+}
+
+// build recursively partitions entities along the longest axis of their bounds,
+// choosing the split point that minimizes the surface area heuristic cost.
+func (bp *bvhBroadPhase) build(entities []ecs.Entity) *bvhNode {
+	if len(entities) == 0 {
+		return nil
+	}
+	if len(entities) == 1 {
+		e := entities[0]
+		return &bvhNode{box: bp.boxes[e], entity: e, leaf: true}
+	}
+
+	bounds := AABB{MinX: math.Inf(1), MinY: math.Inf(1), MaxX: math.Inf(-1), MaxY: math.Inf(-1)}
+	for _, e := range entities {
+		bounds = bounds.Union(bp.boxes[e])
+	}
+
+	onXAxis := (bounds.MaxX - bounds.MinX) >= (bounds.MaxY - bounds.MinY)
+	sort.Slice(entities, func(i, j int) bool {
+		bi, bj := bp.boxes[entities[i]], bp.boxes[entities[j]]
+		if onXAxis {
+			return bi.MinX+bi.MaxX < bj.MinX+bj.MaxX
+		}
+		return bi.MinY+bi.MaxY < bj.MinY+bj.MaxY
+	})
+
+	split := bp.sahSplit(entities)
+	return &bvhNode{
+		box:   bounds,
+		left:  bp.build(entities[:split]),
+		right: bp.build(entities[split:]),
+	}
+}
+
+// sahSplit picks the split index (1..len-1) that minimizes the surface area
+// heuristic cost, leftCount*leftHalfPerimeter + rightCount*rightHalfPerimeter,
+// evaluated over entities already sorted along the chosen axis.
+func (bp *bvhBroadPhase) sahSplit(entities []ecs.Entity) int {
+	n := len(entities)
+	prefix := make([]AABB, n)
+	suffix := make([]AABB, n)
+	prefix[0] = bp.boxes[entities[0]]
+	for i := 1; i < n; i++ {
+		prefix[i] = prefix[i-1].Union(bp.boxes[entities[i]])
+	}
+	suffix[n-1] = bp.boxes[entities[n-1]]
+	for i := n - 2; i >= 0; i-- {
+		suffix[i] = suffix[i+1].Union(bp.boxes[entities[i]])
+	}
+
+	best, bestCost := n/2, math.Inf(1)
+	for split := 1; split < n; split++ {
+		left, right := prefix[split-1], suffix[split]
+		cost := float64(split)*left.halfPerimeter() + float64(n-split)*right.halfPerimeter()
+		if cost < bestCost {
+			bestCost = cost
+			best = split
+		}
+	}
+	return best
+}
+
+// refit pulls current leaf AABBs up to every ancestor without changing the
+// tree's shape, and returns the (possibly new) box for n.
+func (bp *bvhBroadPhase) refit(n *bvhNode) AABB {
+	if n.leaf {
+		n.box = bp.boxes[n.entity]
+		return n.box
+	}
+	n.box = bp.refit(n.left).Union(bp.refit(n.right))
+	return n.box
+}
+
+// selfCollideBVH finds every overlapping leaf pair in the tree rooted at n,
+// appending each to pairs for the caller to sort before reporting.
+func selfCollideBVH(n *bvhNode, pairs *[]pairKey) {
+	if n == nil || n.leaf {
+		return
+	}
+	selfCollideBVH(n.left, pairs)
+	selfCollideBVH(n.right, pairs)
+	crossCollideBVH(n.left, n.right, pairs)
+}
+
+// crossCollideBVH descends into whichever of a/b has the larger box, the
+// standard strategy for finding every overlapping leaf pair across two subtrees.
+func crossCollideBVH(a, b *bvhNode, pairs *[]pairKey) {
+	if a == nil || b == nil || !a.box.Overlaps(b.box) {
+		return
+	}
+	if a.leaf && b.leaf {
+		*pairs = append(*pairs, makePairKey(a.entity, b.entity))
+		return
+	}
+	if b.leaf || (!a.leaf && a.box.halfPerimeter() >= b.box.halfPerimeter()) {
+		crossCollideBVH(a.left, b, pairs)
+		crossCollideBVH(a.right, b, pairs)
+	} else {
+		crossCollideBVH(a, b.left, pairs)
+		crossCollideBVH(a, b.right, pairs)
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"math"
+
+	"github.com/mlange-42/ark/ecs"
+)
+
+// gridBroadPhase is a uniform spatial hash grid BroadPhase. An entity is stored
+// in every cell its AABB overlaps, and cellSize is the caller's choice for how
+// coarse or fine that partitioning should be.
+type gridBroadPhase struct {
+	cellSize float64
+	boxes    map[ecs.Entity]AABB
+	cells    map[[2]int][]ecs.Entity
+	inCells  map[ecs.Entity][][2]int
+}
+
+func newGridBroadPhase(cellSize float64) *gridBroadPhase {
+	return &gridBroadPhase{
+		cellSize: cellSize,
+		boxes:    make(map[ecs.Entity]AABB),
+		cells:    make(map[[2]int][]ecs.Entity),
+		inCells:  make(map[ecs.Entity][][2]int),
+	}
+}
+
+func (g *gridBroadPhase) cellCoord(x, y float64) [2]int {
+	return [2]int{int(math.Floor(x / g.cellSize)), int(math.Floor(y / g.cellSize))}
+}
+
+func (g *gridBroadPhase) cellsFor(box AABB) [][2]int {
+	min := g.cellCoord(box.MinX, box.MinY)
+	max := g.cellCoord(box.MaxX, box.MaxY)
+	cells := make([][2]int, 0, (max[0]-min[0]+1)*(max[1]-min[1]+1))
+	for cx := min[0]; cx <= max[0]; cx++ {
+		for cy := min[1]; cy <= max[1]; cy++ {
+			cells = append(cells, [2]int{cx, cy})
+		}
+	}
+	return cells
+}
+
+func (g *gridBroadPhase) Insert(e ecs.Entity, box AABB) {
+	g.boxes[e] = box
+	cells := g.cellsFor(box)
+	g.inCells[e] = cells
+	for _, c := range cells {
+		g.cells[c] = append(g.cells[c], e)
+	}
+}
+
+func (g *gridBroadPhase) Update(e ecs.Entity, box AABB) {
+	g.Remove(e)
+	g.Insert(e, box)
+}
+
+func (g *gridBroadPhase) Remove(e ecs.Entity) {
+	for _, c := range g.inCells[e] {
+		list := g.cells[c]
+		for i, other := range list {
+			if other == e {
+				list[i] = list[len(list)-1]
+				g.cells[c] = list[:len(list)-1]
+				break
+			}
+		}
+	}
+	delete(g.inCells, e)
+	delete(g.boxes, e)
+}
+
+// gridNeighborOffsets is the "forward" half of the 3x3 neighborhood (plus the
+// cell itself), so every unordered pair of cells is tested exactly once instead
+// of twice.
+var gridNeighborOffsets = [...][2]int{{0, 0}, {1, 0}, {0, 1}, {1, 1}, {-1, 1}}
+
+// QueryPairs tests each cell's entities pairwise against themselves and against
+// the forward neighbor cells, deduplicating by entity-id ordering since an
+// entity spanning multiple cells would otherwise be tested against the same
+// neighbor more than once.
+func (g *gridBroadPhase) QueryPairs(fn func(a, b ecs.Entity)) {
+	seen := make(map[pairKey]bool)
+	pairs := make([]pairKey, 0)
+	for cell, entities := range g.cells {
+		for i := 0; i < len(entities); i++ {
+			for j := i + 1; j < len(entities); j++ {
+				g.tryCollect(entities[i], entities[j], seen, &pairs)
+			}
+		}
+		for _, off := range gridNeighborOffsets[1:] {
+			neighbor := [2]int{cell[0] + off[0], cell[1] + off[1]}
+			for _, a := range entities {
+				for _, b := range g.cells[neighbor] {
+					g.tryCollect(a, b, seen, &pairs)
+				}
+			}
+		}
+	}
+	emitSortedPairs(pairs, fn)
+}
+
+func (g *gridBroadPhase) tryCollect(a, b ecs.Entity, seen map[pairKey]bool, pairs *[]pairKey) {
+	if a == b {
+		return
+	}
+	key := makePairKey(a, b)
+	if seen[key] {
+		return
+	}
+	seen[key] = true
+	if g.boxes[a].Overlaps(g.boxes[b]) {
+		*pairs = append(*pairs, key)
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import "github.com/mlange-42/ark/ecs"
+
+// entitySnapshot is one tick's recorded state for a single entity. Rect is left
+// at its zero value and rectSame is true whenever the entity's Rect is identical
+// to the last tick it was recorded at, so resting or slow-moving entities cost
+// almost nothing to record.
+type entitySnapshot struct {
+	entity   ecs.Entity
+	rect     Rect
+	vel      Velocity
+	rectSame bool
+}
+
+type tickSnapshot struct {
+	entities []entitySnapshot
+}
+
+// History is a ring-buffer of per-tick Rect/Velocity snapshots that lets a world
+// be rewound to an earlier tick. It only depends on the ark World/Map3/Filter3
+// types, so it's reusable by any game built on this module, not just this demo.
+type History struct {
+	world     *ecs.World
+	filter    *ecs.Filter3[Rect, Velocity, Collision]
+	mapObject *ecs.Map3[Rect, Velocity, Collision]
+
+	ticks    []tickSnapshot
+	prevRect map[ecs.Entity]Rect
+	capacity int
+	head     int // index of the next slot to write
+	len      int // number of valid ticks currently stored
+
+	scrubbing bool // true while a chain of Rewind calls is in progress
+	scrubIdx  int  // last tick index restored to by that chain
+}
+
+// NewHistory creates a History that records up to capacity ticks.
+func NewHistory(world *ecs.World, filter *ecs.Filter3[Rect, Velocity, Collision], mapObject *ecs.Map3[Rect, Velocity, Collision], capacity int) *History {
+	return &History{
+		world:     world,
+		filter:    filter,
+		mapObject: mapObject,
+		ticks:     make([]tickSnapshot, capacity),
+		prevRect:  make(map[ecs.Entity]Rect, capacity),
+		capacity:  capacity,
+	}
+}
+
+// Record captures the current Rect/Velocity of every live entity as the next tick,
+// overwriting the oldest tick once the ring buffer is full.
+func (h *History) Record() {
+	h.scrubbing = false
+	snap := tickSnapshot{entities: make([]entitySnapshot, 0, len(h.prevRect))}
+
+	q := h.filter.Query()
+	for q.Next() {
+		rect, vel, _ := q.Get()
+		e := q.Entity()
+
+		prev, seen := h.prevRect[e]
+		same := seen && prev == *rect
+		es := entitySnapshot{entity: e, vel: *vel, rectSame: same}
+		if !same {
+			es.rect = *rect
+			h.prevRect[e] = *rect
+		}
+		snap.entities = append(snap.entities, es)
+	}
+
+	h.ticks[h.head] = snap
+	h.head = (h.head + 1) % h.capacity
+	if h.len < h.capacity {
+		h.len++
+	}
+}
+
+// Rewind restores the world to the snapshot recorded ticksBack ticks before the
+// current scrub position (ticksBack=1 is the most recently recorded tick) and
+// discards every tick recorded after it, so the next Record() continues from
+// that point. Callers typically call Rewind repeatedly across frames (e.g.
+// while a key is held); each call moves ticksBack further back than the last,
+// not just from the original head, via the scrubIdx cursor tracked separately
+// from head so the chain doesn't lose a tick's worth of distance every call.
+func (h *History) Rewind(ticksBack int) {
+	if ticksBack <= 0 || ticksBack > h.len {
+		return
+	}
+	anchor := h.head
+	if h.scrubbing {
+		anchor = h.scrubIdx
+	}
+	idx := (anchor - ticksBack + h.capacity) % h.capacity
+	rects := h.resolveRects(idx)
+
+	for _, es := range h.ticks[idx].entities {
+		if !h.world.Alive(es.entity) {
+			continue
+		}
+		rect := rects[es.entity]
+		vel := es.vel
+		h.mapObject.Set(es.entity, &rect, &vel, &Collision{})
+		h.prevRect[es.entity] = rect
+	}
+
+	h.len -= ticksBack
+	h.head = (idx + 1) % h.capacity
+	h.scrubbing = true
+	h.scrubIdx = idx
+}
+
+// resolveRects reconstructs the actual Rect for every entity recorded at tick idx,
+// following the rectSame chain back to the nearest tick it was last stored at.
+func (h *History) resolveRects(idx int) map[ecs.Entity]Rect {
+	result := make(map[ecs.Entity]Rect, len(h.ticks[idx].entities))
+	pending := make(map[ecs.Entity]bool)
+
+	for _, es := range h.ticks[idx].entities {
+		if es.rectSame {
+			pending[es.entity] = true
+		} else {
+			result[es.entity] = es.rect
+		}
+	}
+
+	// Walk back through every other valid tick, oldest inclusive; steps counts
+	// ticks rather than comparing against h.head so the oldest valid tick
+	// (which sits at index h.head once the ring has wrapped) still gets walked.
+	oldest := (h.head - h.len + h.capacity) % h.capacity
+	steps := (idx - oldest + h.capacity) % h.capacity
+	for i := idx; len(pending) > 0 && steps > 0; steps-- {
+		i = (i - 1 + h.capacity) % h.capacity
+		for _, es := range h.ticks[i].entities {
+			if pending[es.entity] && !es.rectSame {
+				result[es.entity] = es.rect
+				delete(pending, es.entity)
+			}
+		}
+	}
+
+	// An entity still pending hasn't changed Rect across the whole valid
+	// window up to idx, which means it hasn't changed between idx and now
+	// either — so the latest value Record() observed is also its value at idx.
+	for e := range pending {
+		result[e] = h.prevRect[e]
+	}
+	return result
+}
@@ -1,30 +1,32 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"log"
 	"math"
-	"math/rand/v2"
-	"sort"
-	"sync"
+	"time"
 
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
 	"github.com/hajimehoshi/ebiten/v2/inpututil"
 	"github.com/hajimehoshi/ebiten/v2/vector"
-	"github.com/mlange-42/ark/ecs"
 	"golang.org/x/image/colornames"
 )
 
 const (
 	InitialRectangleCount = 1024
-	MaxPossibleObject     = 20000
-	intervalsCap          = MaxPossibleObject * 2
 	IncrementObject       = 500 // Press KeyA
 	ScreenWidth           = 800
 	ScreenHeight          = 600
 )
 
+const (
+	HistorySeconds = 5 // how far back the rewind buffer reaches
+	HistoryTicks   = HistorySeconds * ebiten.DefaultTPS
+	RewindPerFrame = 2 // ticks rewound per frame while KeyR is held
+)
+
 const (
 	RectW      = 4
 	RectH      = 4
@@ -43,29 +45,24 @@ type Collision struct {
 	IsColliding bool
 }
 
+// Game is the Ebiten-facing shell around a Simulation: it turns key presses
+// into Simulation calls and renders the Simulation's current state. It owns no
+// physics or randomness of its own, so the headless Simulation it wraps can be
+// driven identically outside of ebiten.RunGame.
 type Game struct {
-	world        ecs.World
-	filter       *ecs.Filter3[Rect, Velocity, Collision]
-	mapObject    ecs.Map3[Rect, Velocity, Collision]
-	mapCollision ecs.Map1[Collision]
-	w, h         float64
-	intervals    []Interval // interval pool for SAP
-	pool         sync.Pool
-	activeList   []ecs.Entity // Pre-allocated active list
-	activeLen    int          // Current length of active list
-}
-
-// Interval structure represents intervals used for the SAP (Sweep and Prune) algorithm
-type Interval struct {
-	// Xaxis represents the position of the rectangle's edge on the x-axis
-	Xaxis float64
-	// IsLeftEdge indicates if this is the left edge (true) or right edge (false) of the rectangle
-	IsLeftEdge bool
-	// Entity holds the reference to the entity this interval belongs to
-	Entity ecs.Entity
+	sim *Simulation
 }
 
 func main() {
+	bench := flag.Bool("bench", false, "run the broad-phase benchmark harness and exit")
+	flag.Parse()
+	if *bench {
+		for _, r := range RunBroadPhaseBenchmark(5000, 120) {
+			fmt.Printf("%-28s %12.0f pairs/sec %8.3f ms/frame\n", r.Name, r.PairsPerSec, r.MsPerFrame)
+		}
+		return
+	}
+
 	g := NewGame()
 
 	ebiten.SetWindowSize(ScreenWidth, ScreenHeight)
@@ -75,135 +72,69 @@ func main() {
 	}
 }
 
+// NewGame seeds a fresh Simulation from the current time, since interactive
+// play has no reproducibility requirement. Reproducible runs should build a
+// Simulation directly via NewSimulation with a fixed seed instead.
 func NewGame() *Game {
-	g := &Game{}
-	g.w = float64(ScreenWidth)
-	g.h = float64(ScreenHeight)
-	g.world = ecs.NewWorld(InitialRectangleCount)
-	g.filter = ecs.NewFilter3[Rect, Velocity, Collision](&g.world)
-	g.activeList = make([]ecs.Entity, MaxPossibleObject)
-	g.mapObject = ecs.NewMap3[Rect, Velocity, Collision](&g.world)
-	g.intervals = make([]Interval, 0, intervalsCap)
-	g.mapCollision = ecs.NewMap1[Collision](&g.world)
-	g.pool = sync.Pool{
-		New: func() interface{} {
-			return &Interval{}
-		},
-	}
-	g.SpawnRectangles(InitialRectangleCount)
-	return g
+	sim := NewSimulation(uint64(time.Now().UnixNano()), SimulationConfig{
+		Width:                 ScreenWidth,
+		Height:                ScreenHeight,
+		InitialRectangleCount: InitialRectangleCount,
+		HistoryTicks:          HistoryTicks,
+	})
+	return &Game{sim: sim}
 }
 
 func (g *Game) Update() error {
+	// Hold 'R' to scrub the simulation backward using the rewind buffer
+	if ebiten.IsKeyPressed(ebiten.KeyR) {
+		g.sim.Rewind(RewindPerFrame)
+		return nil
+	}
+
 	// Add new entities when 'A' key is pressed
 	if inpututil.IsKeyJustPressed(ebiten.KeyA) {
-		g.SpawnRectangles(IncrementObject)
+		g.sim.SpawnRectangles(IncrementObject)
 	}
 
-	// Reset SAP (Sweep and Prune) data structures
-	g.intervals = g.intervals[:0]
-	g.activeLen = 0
-
-	// Update positions and build SAP intervals
-	q := g.filter.Query()
-	for q.Next() {
-		rect, vel, coll := q.Get()
-		// Apply velocity to position
-		rect.X += vel.X
-		rect.Y += vel.Y
-
-		// Handle screen boundary collisions
-		handleScreenBoundaryCollision(rect, vel, g.w, g.h)
-
-		// Reset collision state for new frame
-		coll.IsColliding = false
-		e := q.Entity()
-
-		// Add entity bounds to SAP intervals
-		interval1 := g.pool.Get().(*Interval)
-		interval1.Xaxis = rect.X
-		interval1.IsLeftEdge = true
-		interval1.Entity = e
-		g.intervals = append(g.intervals, *interval1)
-
-		interval2 := g.pool.Get().(*Interval)
-		interval2.Xaxis = rect.X + rect.W
-		interval2.IsLeftEdge = false
-		interval2.Entity = e
-		g.intervals = append(g.intervals, *interval2)
+	// Toggle Discrete/Continuous collision detection with 'C'
+	if inpututil.IsKeyJustPressed(ebiten.KeyC) {
+		g.sim.CollisionMode = 1 - g.sim.CollisionMode
 	}
 
-	// Sort intervals once
-	sort.Slice(g.intervals, func(i, j int) bool {
-		return g.intervals[i].Xaxis < g.intervals[j].Xaxis
-	})
-
-	// Sweep phase with pre-allocated active list
-	for _, interval := range g.intervals {
-		if interval.IsLeftEdge {
-			// Check collisions with current active entities
-			r1, v1, c1 := g.mapObject.Get(interval.Entity)
-
-			for i := 0; i < g.activeLen; i++ {
-				e2 := g.activeList[i]
-				r2, v2, c2 := g.mapObject.Get(e2)
-
-				// AABB overlap test
-				if r1.Y < r2.Y+r2.H && r1.Y+r1.H > r2.Y {
-					c1.IsColliding = true
-					c2.IsColliding = true
-
-					// Separate objects
-					overlapX := math.Min(r1.X+r1.W, r2.X+r2.W) - math.Max(r1.X, r2.X)
-					overlapY := math.Min(r1.Y+r1.H, r2.Y+r2.H) - math.Max(r1.Y, r2.Y)
-
-					// Determine separation direction
-					if overlapX < overlapY {
-						// Separate on X axis
-						if r1.X < r2.X {
-							r1.X -= overlapX / 2
-							r2.X += overlapX / 2
-						} else {
-							r1.X += overlapX / 2
-							r2.X -= overlapX / 2
-						}
-						// Simple velocity exchange
-						v1.X, v2.X = v2.X, v1.X
-					} else {
-						// Separate on Y axis
-						if r1.Y < r2.Y {
-							r1.Y -= overlapY / 2
-							r2.Y += overlapY / 2
-						} else {
-							r1.Y += overlapY / 2
-							r2.Y -= overlapY / 2
-						}
-						// Simple velocity exchange
-						v1.Y, v2.Y = v2.Y, v1.Y
-					}
-				}
-			}
+	g.sim.Step()
+	return nil
+}
 
-			// Add to active list
-			if g.activeLen < len(g.activeList) {
-				g.activeList[g.activeLen] = interval.Entity
-				g.activeLen++
-			}
+// resolveCollision separates two overlapping rects along their shallower axis and
+// exchanges velocity on that axis.
+func resolveCollision(r1 *Rect, v1 *Velocity, r2 *Rect, v2 *Velocity) {
+	overlapX := math.Min(r1.X+r1.W, r2.X+r2.W) - math.Max(r1.X, r2.X)
+	overlapY := math.Min(r1.Y+r1.H, r2.Y+r2.H) - math.Max(r1.Y, r2.Y)
+
+	if overlapX < overlapY {
+		// Separate on X axis
+		if r1.X < r2.X {
+			r1.X -= overlapX / 2
+			r2.X += overlapX / 2
 		} else {
-			// Remove from active list
-			for i := 0; i < g.activeLen; i++ {
-				if g.activeList[i] == interval.Entity {
-					g.activeList[i] = g.activeList[g.activeLen-1]
-					g.activeLen--
-					break
-				}
-			}
+			r1.X += overlapX / 2
+			r2.X -= overlapX / 2
 		}
-
-		// Interval nesnesini havuza geri gönder
-		g.pool.Put(&interval)
+		// Simple velocity exchange
+		v1.X, v2.X = v2.X, v1.X
+	} else {
+		// Separate on Y axis
+		if r1.Y < r2.Y {
+			r1.Y -= overlapY / 2
+			r2.Y += overlapY / 2
+		} else {
+			r1.Y += overlapY / 2
+			r2.Y -= overlapY / 2
+		}
+		// Simple velocity exchange
+		v1.Y, v2.Y = v2.Y, v1.Y
 	}
-	return nil
 }
 
 // handleScreenBoundaryCollision handles collisions with the screen boundaries
@@ -228,7 +159,7 @@ func handleScreenBoundaryCollision(rect *Rect, vel *Velocity, screenWidth, scree
 }
 
 func (g *Game) Draw(screen *ebiten.Image) {
-	q := g.filter.Query()
+	q := g.sim.filter.Query()
 	for q.Next() {
 		rect, _, c := q.Get()
 		clr := colornames.Green
@@ -238,30 +169,18 @@ func (g *Game) Draw(screen *ebiten.Image) {
 		vector.DrawFilledRect(screen, float32(rect.X), float32(rect.Y), float32(rect.W), float32(rect.H), clr, false)
 	}
 
-	ebitenutil.DebugPrintAt(screen, fmt.Sprintf("FPS: %0.2f\nTPS: %0.2f\nEntities: %v",
+	mode := "Discrete"
+	if g.sim.CollisionMode == Continuous {
+		mode = "Continuous"
+	}
+	ebitenutil.DebugPrintAt(screen, fmt.Sprintf("FPS: %0.2f\nTPS: %0.2f\nEntities: %v\nCollision mode: %s (toggle with C)\nHold R to rewind",
 		ebiten.ActualFPS(),
 		ebiten.ActualTPS(),
-		g.world.Stats().Entities),
+		g.sim.world.Stats().Entities,
+		mode),
 		10, 10)
 }
 
 func (g *Game) Layout(w, h int) (int, int) {
-	return int(g.w), int(g.h)
-}
-
-func (g *Game) SpawnRectangles(n int) {
-	g.mapObject.NewBatchFn(n, func(entity ecs.Entity, r *Rect, v *Velocity, c *Collision) {
-		if RandomSize {
-			r.W = 2 + rand.Float64()*18
-			r.H = 2 + rand.Float64()*18
-		} else {
-			r.W = RectW
-			r.H = RectH
-		}
-		r.X = rand.Float64() * (g.w - r.W)
-		r.Y = rand.Float64() * (g.h - r.H)
-		v.X = -1 + rand.Float64()*2
-		v.Y = -1 + rand.Float64()*2
-		c.IsColliding = false
-	})
+	return int(g.sim.w), int(g.sim.h)
 }
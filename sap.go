@@ -0,0 +1,16 @@
+package main
+
+import "github.com/mlange-42/ark/ecs"
+
+// pairKey canonically identifies an unordered pair of entities, ordered by ID so
+// that (a, b) and (b, a) hash to the same key.
+type pairKey struct {
+	a, b ecs.Entity
+}
+
+func makePairKey(e1, e2 ecs.Entity) pairKey {
+	if e1.ID() > e2.ID() {
+		e1, e2 = e2, e1
+	}
+	return pairKey{e1, e2}
+}
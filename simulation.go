@@ -0,0 +1,157 @@
+package main
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"math"
+	"math/rand/v2"
+
+	"github.com/mlange-42/ark/ecs"
+)
+
+// SimulationConfig bundles the parameters NewSimulation needs to build a world
+// equivalent to the interactive Game's, minus anything Ebiten-specific.
+type SimulationConfig struct {
+	Width, Height         float64
+	InitialRectangleCount int
+	HistoryTicks          int
+	CollisionMode         CollisionMode
+
+	// BroadPhaseKind picks the BroadPhase implementation; zero value is SAP.
+	BroadPhaseKind BroadPhaseKind
+	// GridCellSize tunes GridBroadPhase; zero uses newBroadPhase's default of 32.
+	GridCellSize float64
+	// BVHRebuildEvery tunes BVHBroadPhase; zero uses newBroadPhase's default of 30.
+	BVHRebuildEvery int
+}
+
+// Simulation runs the SAP collision demo's physics with no Ebiten dependency,
+// driven by a fixed-step Step() call and a seeded RNG instead of the global
+// math/rand/v2 source. Two Simulations built with the same seed and config
+// evolve identically tick for tick, which makes this suitable for headless
+// benchmarks, property-based tests, and — longer term — rollback netcode that
+// resimulates from a known-good state once authoritative inputs arrive.
+type Simulation struct {
+	world        ecs.World
+	filter       *ecs.Filter3[Rect, Velocity, Collision]
+	mapObject    *ecs.Map3[Rect, Velocity, Collision]
+	mapCollision *ecs.Map1[Collision]
+	w, h         float64
+
+	broadPhase BroadPhase
+	motion     map[ecs.Entity]motionRecord
+	history    *History
+
+	CollisionMode CollisionMode
+
+	rng  *rand.Rand
+	tick uint64
+}
+
+// NewSimulation creates a headless Simulation seeded deterministically: every
+// call with the same seed and config produces the same initial scene and, tick
+// for tick, the same evolution — including SpawnRectangles calls made later.
+func NewSimulation(seed uint64, config SimulationConfig) *Simulation {
+	s := &Simulation{
+		w:             config.Width,
+		h:             config.Height,
+		CollisionMode: config.CollisionMode,
+		rng:           rand.New(rand.NewPCG(seed, seed)),
+	}
+	s.world = ecs.NewWorld(config.InitialRectangleCount)
+	s.filter = ecs.NewFilter3[Rect, Velocity, Collision](&s.world)
+	s.mapObject = ecs.NewMap3[Rect, Velocity, Collision](&s.world)
+	s.mapCollision = ecs.NewMap1[Collision](&s.world)
+	s.broadPhase = newBroadPhase(config)
+	s.motion = make(map[ecs.Entity]motionRecord, config.InitialRectangleCount)
+	s.history = NewHistory(&s.world, s.filter, s.mapObject, config.HistoryTicks)
+	s.SpawnRectangles(config.InitialRectangleCount)
+	return s
+}
+
+// SpawnRectangles adds n randomly placed rectangles, drawing every random value
+// from the Simulation's own seeded RNG rather than the global math/rand/v2
+// source, so the resulting scene is identical across machines for the same seed.
+func (s *Simulation) SpawnRectangles(n int) {
+	s.mapObject.NewBatchFn(n, func(entity ecs.Entity, r *Rect, v *Velocity, c *Collision) {
+		if RandomSize {
+			r.W = 2 + s.rng.Float64()*18
+			r.H = 2 + s.rng.Float64()*18
+		} else {
+			r.W = RectW
+			r.H = RectH
+		}
+		r.X = s.rng.Float64() * (s.w - r.W)
+		r.Y = s.rng.Float64() * (s.h - r.H)
+		v.X = -1 + s.rng.Float64()*2
+		v.Y = -1 + s.rng.Float64()*2
+		c.IsColliding = false
+
+		s.broadPhase.Insert(entity, AABBFromRect(*r))
+	})
+}
+
+// Step advances the simulation by one fixed tick: integrate motion, refresh the
+// broad phase, resolve collisions, and record history.
+func (s *Simulation) Step() {
+	q := s.filter.Query()
+	for q.Next() {
+		rect, vel, coll := q.Get()
+		start, startVel := *rect, *vel
+
+		rect.X += vel.X
+		rect.Y += vel.Y
+		handleScreenBoundaryCollision(rect, vel, s.w, s.h)
+		coll.IsColliding = false
+
+		e := q.Entity()
+		box := AABBFromRect(*rect)
+		if s.CollisionMode == Continuous {
+			box = sweptAABB(AABBFromRect(start), startVel)
+			s.motion[e] = motionRecord{start: start, vel: startVel}
+		}
+		s.broadPhase.Update(e, box)
+	}
+
+	s.broadPhase.QueryPairs(func(a, b ecs.Entity) {
+		r1, v1, c1 := s.mapObject.Get(a)
+		r2, v2, c2 := s.mapObject.Get(b)
+
+		var collided bool
+		if s.CollisionMode == Continuous {
+			collided = s.resolveContinuous(a, b, r1, v1, r2, v2)
+		} else {
+			resolveCollision(r1, v1, r2, v2)
+			collided = true
+		}
+		if collided {
+			c1.IsColliding = true
+			c2.IsColliding = true
+		}
+	})
+
+	s.history.Record()
+	s.tick++
+}
+
+// Rewind restores the simulation to the snapshot recorded ticksBack ticks ago.
+func (s *Simulation) Rewind(ticksBack int) {
+	s.history.Rewind(ticksBack)
+}
+
+// Checksum hashes every live entity's Rect and Velocity into one uint64, so two
+// Simulation runs from the same seed can be compared for bit-for-bit equality
+// tick by tick in property-based tests.
+func (s *Simulation) Checksum() uint64 {
+	h := fnv.New64a()
+	var buf [8]byte
+	q := s.filter.Query()
+	for q.Next() {
+		rect, vel, _ := q.Get()
+		for _, v := range [...]float64{rect.X, rect.Y, rect.W, rect.H, vel.X, vel.Y} {
+			binary.LittleEndian.PutUint64(buf[:], math.Float64bits(v))
+			h.Write(buf[:])
+		}
+	}
+	return h.Sum64()
+}